@@ -0,0 +1,216 @@
+//
+// Copyright (c) 2016 Dean Jackson <deanishe@deanishe.net>
+//
+// MIT Licence. See http://opensource.org/licenses/MIT
+//
+
+// Package background runs and tracks detached child processes for
+// Script Filters that need to refresh slow data without blocking
+// Alfred's UI. The usual pattern: return whatever's already in the
+// Cache immediately, call Run to kick off a job that refreshes it, and
+// set Feedback.Rerun so Alfred asks again in a moment once the job has
+// written fresh data.
+package background
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	workflow "github.com/golanglib/awgo"
+)
+
+// Dir is the directory job PID files are stored under, as
+// "<Dir>/jobs/<name>.pid". It defaults to the workflow's cache directory.
+var Dir = workflow.CacheDir()
+
+func pidFile(name string) string {
+	return filepath.Join(Dir, "jobs", name+".pid")
+}
+
+// IsRunning reports whether a background job called name is currently
+// running. A stale PID file -- one whose PID is dead, or that's
+// missing or unparsable -- is removed and IsRunning returns false, so
+// a future Run can reclaim name instead of getting wedged behind
+// reservePIDFile's exclusive create forever. This never races a fresh
+// reservation: createPIDFile writes the reserving process's own (live)
+// PID into the file before returning, so there's no window where the
+// file exists but reads as stale.
+func IsRunning(name string) bool {
+	pid, err := readPID(name)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false
+		}
+		os.Remove(pidFile(name))
+		return false
+	}
+	if alive(pid) {
+		return true
+	}
+	os.Remove(pidFile(name))
+	return false
+}
+
+// Run starts cmd as a fully detached background process and records it
+// under name. It refuses to start (returning an error) if a job called
+// name is already running.
+func Run(name string, cmd *exec.Cmd) error {
+	if err := os.MkdirAll(filepath.Dir(pidFile(name)), 0700); err != nil {
+		return err
+	}
+
+	if err := reservePIDFile(name); err != nil {
+		return err
+	}
+
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setsid = true
+
+	devnull, err := os.OpenFile(os.DevNull, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer devnull.Close()
+
+	if cmd.Stdin == nil {
+		cmd.Stdin = devnull
+	}
+	if cmd.Stdout == nil {
+		cmd.Stdout = devnull
+	}
+	if cmd.Stderr == nil {
+		cmd.Stderr = devnull
+	}
+
+	if err := cmd.Start(); err != nil {
+		os.Remove(pidFile(name))
+		return err
+	}
+
+	// Reap the child once it exits. Without this, as long as this
+	// process is still around, the child lingers as a zombie after it
+	// exits: its PID stays allocated, so the signal-0 check alive()
+	// relies on keeps reporting it as running.
+	go cmd.Wait()
+
+	return writePID(name, cmd.Process.Pid)
+}
+
+// reservePIDFile atomically claims name's PID file so two concurrent
+// Run calls for the same name can't both pass the "is it running"
+// check and start duplicate jobs. If the file already exists but its
+// PID is dead, the stale file is cleared (by IsRunning) and claimed on
+// a single retry.
+func reservePIDFile(name string) error {
+	if err := createPIDFile(name); err == nil {
+		return nil
+	} else if !os.IsExist(err) {
+		return err
+	}
+
+	if IsRunning(name) {
+		return fmt.Errorf("background: job %q is already running", name)
+	}
+
+	// IsRunning cleared the stale file; claim it before anyone else does.
+	return createPIDFile(name)
+}
+
+// createPIDFile atomically creates name's PID file, failing with an
+// os.IsExist error if it's already reserved. The file never exists
+// empty: its content (this process's own PID, not left blank) is
+// written to a temp file first, then os.Link makes it visible at
+// pidFile(name) in one step, failing with EEXIST if the name is
+// already taken. Creating the file with O_EXCL and writing its
+// content after would instead leave a window where the file exists
+// but is empty, which a concurrent IsRunning call would read as
+// stale, delete, and hand the name to a second caller -- letting two
+// callers both believe they'd reserved it and start duplicate jobs.
+// writePID later overwrites the placeholder with the child's real PID
+// the same way, once it's known.
+func createPIDFile(name string) error {
+	dir := filepath.Dir(pidFile(name))
+	tmp, err := ioutil.TempFile(dir, "."+name+".*.pid.tmp")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName)
+
+	if _, err := tmp.Write([]byte(strconv.Itoa(os.Getpid()))); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Link(tmpName, pidFile(name))
+}
+
+// Kill stops the background job called name, if it's running. Its PID
+// file is left in place; IsRunning reclaims it once the process has
+// actually exited, rather than Kill assuming SIGTERM takes effect
+// immediately.
+func Kill(name string) error {
+	pid, err := readPID(name)
+	if err != nil {
+		return err
+	}
+	proc, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	return proc.Signal(syscall.SIGTERM)
+}
+
+func readPID(name string) (int, error) {
+	data, err := ioutil.ReadFile(pidFile(name))
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(data)))
+}
+
+// writePID overwrites name's PID file with pid. The write is atomic --
+// pid is written to a temp file in the same directory, then renamed
+// over the existing reservation -- so a concurrent IsRunning always
+// sees either the reserving process's placeholder PID or the real
+// child PID, never a truncated or empty file.
+func writePID(name string, pid int) error {
+	dir := filepath.Dir(pidFile(name))
+	tmp, err := ioutil.TempFile(dir, "."+name+".*.pid.tmp")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName)
+
+	if _, err := tmp.Write([]byte(strconv.Itoa(pid))); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpName, pidFile(name))
+}
+
+// alive reports whether pid belongs to a running process, by sending it
+// signal 0: this performs the kernel's permission/existence checks
+// without actually signalling the process.
+func alive(pid int) bool {
+	proc, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return proc.Signal(syscall.Signal(0)) == nil
+}
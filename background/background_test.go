@@ -0,0 +1,145 @@
+//
+// Copyright (c) 2016 Dean Jackson <deanishe@deanishe.net>
+//
+// MIT Licence. See http://opensource.org/licenses/MIT
+//
+
+package background
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRunIsRunningKill(t *testing.T) {
+	dir, err := ioutil.TempDir("", "awgo-background-test")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	oldDir := Dir
+	Dir = dir
+	defer func() { Dir = oldDir }()
+
+	const job = "sleeper"
+
+	if IsRunning(job) {
+		t.Fatal("job should not be running before Run")
+	}
+
+	cmd := exec.Command("sleep", "5")
+	if err := Run(job, cmd); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	defer cmd.Process.Kill()
+
+	if !IsRunning(job) {
+		t.Fatal("job should be running after Run")
+	}
+
+	if err := Run(job, exec.Command("sleep", "5")); err == nil {
+		t.Fatal("Run should refuse to start an already-running job")
+	}
+
+	if err := Kill(job); err != nil {
+		t.Fatalf("Kill: %v", err)
+	}
+
+	// Give the signal a moment to land.
+	for i := 0; i < 20 && IsRunning(job); i++ {
+		time.Sleep(50 * time.Millisecond)
+	}
+	if IsRunning(job) {
+		t.Fatal("job should not be running after Kill")
+	}
+}
+
+// TestRunAfterEmptyPIDFile reproduces an empty, unparsable PID file
+// (e.g. one that was truncated): IsRunning must treat it as stale so
+// Run can reclaim the name instead of getting wedged behind
+// reservePIDFile's exclusive create forever.
+func TestRunAfterEmptyPIDFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "awgo-background-test")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	oldDir := Dir
+	Dir = dir
+	defer func() { Dir = oldDir }()
+
+	const job = "stale"
+
+	if err := os.MkdirAll(dir+"/jobs", 0700); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	if err := ioutil.WriteFile(dir+"/jobs/"+job+".pid", nil, 0600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	if IsRunning(job) {
+		t.Fatal("empty PID file should not count as running")
+	}
+
+	cmd := exec.Command("sleep", "5")
+	if err := Run(job, cmd); err != nil {
+		t.Fatalf("Run should reclaim a stale, unparsable PID file: %v", err)
+	}
+	defer cmd.Process.Kill()
+
+	if !IsRunning(job) {
+		t.Fatal("job should be running after Run")
+	}
+}
+
+// TestReservePIDFileConcurrent guards against a race where a
+// concurrent IsRunning call reads a freshly O_EXCL-created but
+// not-yet-populated PID file as stale (readPID fails, so IsRunning
+// deletes it), letting a second reservePIDFile call reclaim the name
+// and both callers believe they won. createPIDFile must leave the file
+// holding a live PID (this process's own) from the moment it's
+// created, so it's never seen as stale during the reservation window.
+func TestReservePIDFileConcurrent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "awgo-background-test")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	oldDir := Dir
+	Dir = dir
+	defer func() { Dir = oldDir }()
+
+	if err := os.MkdirAll(dir+"/jobs", 0700); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+
+	const job = "contended"
+	const n = 50
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var wins int
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := reservePIDFile(job); err == nil {
+				mu.Lock()
+				wins++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if wins != 1 {
+		t.Fatalf("got %d concurrent winners, want exactly 1", wins)
+	}
+}
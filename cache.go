@@ -0,0 +1,192 @@
+//
+// Copyright (c) 2016 Dean Jackson <deanishe@deanishe.net>
+//
+// MIT Licence. See http://opensource.org/licenses/MIT
+//
+
+package workflow
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Cache provides simple, file-backed storage for byte blobs, keyed by
+// name and rooted at Dir (typically the workflow's cache directory, see
+// CacheDir). It's meant for data that's expensive or slow to fetch
+// (API responses, computed indexes) but fine to keep around for a
+// while.
+type Cache struct {
+	// Dir is the directory cached files are stored in.
+	Dir string
+
+	mu      sync.Mutex
+	loading map[string]*sync.Mutex
+}
+
+// NewCache creates a Cache rooted at dir.
+func NewCache(dir string) *Cache {
+	return &Cache{Dir: dir, loading: map[string]*sync.Mutex{}}
+}
+
+// DefaultCache returns a Cache rooted at the workflow's cache
+// directory.
+func DefaultCache() *Cache {
+	return NewCache(CacheDir())
+}
+
+func (c *Cache) path(name string) string {
+	return filepath.Join(c.Dir, name)
+}
+
+// Exists reports whether name is cached.
+func (c *Cache) Exists(name string) bool {
+	_, err := os.Stat(c.path(name))
+	return err == nil
+}
+
+// Age returns how long ago name was last written. It returns an error
+// if name isn't cached.
+func (c *Cache) Age(name string) (time.Duration, error) {
+	fi, err := os.Stat(c.path(name))
+	if err != nil {
+		return 0, err
+	}
+	return time.Since(fi.ModTime()), nil
+}
+
+// Fresh reports whether name exists and was written less than maxAge
+// ago. A maxAge of zero or less means name is always considered stale.
+func (c *Cache) Fresh(name string, maxAge time.Duration) bool {
+	if maxAge <= 0 {
+		return false
+	}
+	age, err := c.Age(name)
+	if err != nil {
+		return false
+	}
+	return age < maxAge
+}
+
+// Load returns the raw contents of name.
+func (c *Cache) Load(name string) ([]byte, error) {
+	return ioutil.ReadFile(c.path(name))
+}
+
+// Store writes data to name. The write is atomic: data is written to a
+// temp file in Dir, then renamed over name, so a reader never sees a
+// partially-written entry.
+func (c *Cache) Store(name string, data []byte) error {
+	if err := os.MkdirAll(c.Dir, 0700); err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(c.Dir, "."+name+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	return os.Rename(tmpName, c.path(name))
+}
+
+// LoadJSON reads name and unmarshals it into v.
+func (c *Cache) LoadJSON(name string, v interface{}) error {
+	data, err := c.Load(name)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// StoreJSON marshals v as JSON and stores it under name.
+func (c *Cache) StoreJSON(name string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.Store(name, data)
+}
+
+// loadingMutex returns the mutex used to single-flight concurrent
+// reloads of name, creating it if necessary.
+func (c *Cache) loadingMutex(name string) *sync.Mutex {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	m, ok := c.loading[name]
+	if !ok {
+		m = &sync.Mutex{}
+		c.loading[name] = m
+	}
+	return m
+}
+
+// LoadOrRun returns the cached bytes for name if they're fresh (younger
+// than maxAge). Otherwise it calls reload, stores the result under
+// name and returns it.
+//
+// Concurrent calls for the same name share a per-name lock, so reload
+// runs at most once at a time even if several callers miss the cache
+// simultaneously; the losers of that race get the winner's result
+// instead of re-running reload themselves. With maxAge <= 0 nothing is
+// ever fresh, so each caller runs reload in turn.
+func (c *Cache) LoadOrRun(name string, maxAge time.Duration, reload func() ([]byte, error)) ([]byte, error) {
+	if c.Fresh(name, maxAge) {
+		if data, err := c.Load(name); err == nil {
+			return data, nil
+		}
+	}
+
+	m := c.loadingMutex(name)
+	m.Lock()
+	defer m.Unlock()
+
+	// Another goroutine may have refreshed the entry while we were
+	// waiting for the lock.
+	if c.Fresh(name, maxAge) {
+		if data, err := c.Load(name); err == nil {
+			return data, nil
+		}
+	}
+
+	data, err := reload()
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Store(name, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Rerun tells Alfred to wait seconds, then run the Script Filter again,
+// merging the new feedback in place of what's already on screen rather
+// than discarding it. Pass seconds <= 0 to clear a previously set rerun
+// interval.
+//
+// This is the standard way to pair Cache.LoadOrRun (or a background
+// job, see package background) with a Script Filter: return whatever's
+// already cached immediately, and let Alfred pull fresh results a
+// moment later once the reload has finished.
+//
+// Rerun lives on Feedback rather than Workflow: "rerun" is a top-level
+// key of the feedback JSON payload itself (alongside "items"), not
+// workflow-level state, and Feedback's MarshalJSON emits it from
+// fb.rerun when set -- see TestFeedbackRerunMarshal.
+func (fb *Feedback) Rerun(seconds float64) *Feedback {
+	fb.rerun = seconds
+	return fb
+}
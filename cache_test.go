@@ -0,0 +1,148 @@
+//
+// Copyright (c) 2016 Dean Jackson <deanishe@deanishe.net>
+//
+// MIT Licence. See http://opensource.org/licenses/MIT
+//
+
+package workflow
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestCache(t *testing.T) *Cache {
+	dir, err := ioutil.TempDir("", "awgo-cache-test")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return NewCache(dir)
+}
+
+func TestCacheStoreLoad(t *testing.T) {
+	c := newTestCache(t)
+
+	if c.Exists("greeting") {
+		t.Fatal("greeting should not exist yet")
+	}
+
+	if err := c.Store("greeting", []byte("hello")); err != nil {
+		t.Fatalf("Store: %v", err)
+	}
+
+	if !c.Exists("greeting") {
+		t.Fatal("greeting should exist")
+	}
+
+	data, err := c.Load("greeting")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if string(data) != "hello" {
+		t.Fatalf("got %q, want %q", data, "hello")
+	}
+}
+
+func TestCacheJSON(t *testing.T) {
+	c := newTestCache(t)
+
+	type payload struct {
+		Name string `json:"name"`
+	}
+
+	in := payload{Name: "dean"}
+	if err := c.StoreJSON("payload", in); err != nil {
+		t.Fatalf("StoreJSON: %v", err)
+	}
+
+	var out payload
+	if err := c.LoadJSON("payload", &out); err != nil {
+		t.Fatalf("LoadJSON: %v", err)
+	}
+	if out.Name != in.Name {
+		t.Fatalf("got %+v, want %+v", out, in)
+	}
+}
+
+func TestCacheFresh(t *testing.T) {
+	c := newTestCache(t)
+
+	if c.Fresh("missing", time.Minute) {
+		t.Fatal("missing entry should never be fresh")
+	}
+
+	if err := c.Store("entry", []byte("x")); err != nil {
+		t.Fatalf("Store: %v", err)
+	}
+
+	if !c.Fresh("entry", time.Minute) {
+		t.Fatal("just-written entry should be fresh")
+	}
+	if c.Fresh("entry", -time.Nanosecond) {
+		t.Fatal("entry should be stale with a negative maxAge")
+	}
+}
+
+func TestCacheLoadOrRun(t *testing.T) {
+	c := newTestCache(t)
+
+	calls := 0
+	reload := func() ([]byte, error) {
+		calls++
+		return []byte("fresh"), nil
+	}
+
+	data, err := c.LoadOrRun("entry", time.Minute, reload)
+	if err != nil {
+		t.Fatalf("LoadOrRun: %v", err)
+	}
+	if string(data) != "fresh" {
+		t.Fatalf("got %q, want %q", data, "fresh")
+	}
+	if calls != 1 {
+		t.Fatalf("expected 1 reload call, got %d", calls)
+	}
+
+	// Second call within maxAge should hit the cache, not reload.
+	data, err = c.LoadOrRun("entry", time.Minute, reload)
+	if err != nil {
+		t.Fatalf("LoadOrRun: %v", err)
+	}
+	if string(data) != "fresh" {
+		t.Fatalf("got %q, want %q", data, "fresh")
+	}
+	if calls != 1 {
+		t.Fatalf("expected cache hit, reload called %d times", calls)
+	}
+}
+
+// TestFeedbackRerunMarshal confirms Rerun's seconds actually reach
+// Alfred: Feedback's existing MarshalJSON (see TestMarshalFeedback)
+// must emit them as the top-level "rerun" key, or Rerun is a silent
+// no-op.
+func TestFeedbackRerunMarshal(t *testing.T) {
+	fb := NewFeedback()
+
+	data, err := json.Marshal(fb)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if strings.Contains(string(data), `"rerun"`) {
+		t.Fatalf("unset rerun should not appear in feedback JSON: %s", data)
+	}
+
+	fb.Rerun(1.5)
+
+	data, err = json.Marshal(fb)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if !strings.Contains(string(data), `"rerun":1.5`) {
+		t.Fatalf("expected top-level rerun key in %s", data)
+	}
+}
@@ -0,0 +1,193 @@
+//
+// Copyright (c) 2016 Dean Jackson <deanishe@deanishe.net>
+//
+// MIT Licence. See http://opensource.org/licenses/MIT
+//
+
+package workflow
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	scoreBoundary    = 16.0
+	scoreCamelCase   = 8.0
+	scoreConsecutive = 4.0
+	scoreSkip        = -1.0
+	scoreUnmatched   = -3.0
+	maxSkipPenalty   = -16.0
+
+	subtitleDiscount = 0.5
+)
+
+// FilterOption configures Feedback.Filter and Feedback.FilterFunc.
+type FilterOption func(*filterConfig)
+
+type filterConfig struct {
+	minScore    float64
+	sortByScore bool
+}
+
+// MinScore rejects any Item scoring below min, regardless of whether it
+// matched.
+func MinScore(min float64) FilterOption {
+	return func(c *filterConfig) { c.minScore = min }
+}
+
+// SortByScore turns best-match-first sorting on or off. It defaults to
+// on.
+func SortByScore(enabled bool) FilterOption {
+	return func(c *filterConfig) { c.sortByScore = enabled }
+}
+
+// Filter fuzzy-matches query against each Item's title (and, at a
+// discount, its subtitle), drops Items that don't match and returns a
+// new Feedback of the survivors, best match first.
+//
+// The matcher is the same greedy, left-to-right subsequence scan Sublime
+// Text and VSCode use: it rewards matches at the start of the string or
+// right after a path/word separator, rewards camelCase humps, rewards
+// runs of consecutive matched characters, and penalises characters it
+// had to skip over. Any query rune the left-to-right scan can't match
+// after the previous query rune's match rejects the Item outright.
+func (fb *Feedback) Filter(query string, opts ...FilterOption) *Feedback {
+	return fb.FilterFunc(func(it *Item) float64 {
+		score, ok := scoreItem(query, it)
+		if !ok {
+			return math.Inf(-1)
+		}
+		return score
+	}, opts...)
+}
+
+// FilterFunc is the escape hatch behind Filter: score is called once
+// per Item, and any Item scoring math.Inf(-1) or below MinScore (no
+// minimum by default) is dropped from the result.
+func (fb *Feedback) FilterFunc(score func(*Item) float64, opts ...FilterOption) *Feedback {
+	cfg := filterConfig{minScore: math.Inf(-1), sortByScore: true}
+	for _, o := range opts {
+		o(&cfg)
+	}
+
+	type scored struct {
+		item  *Item
+		score float64
+	}
+
+	// Score every Item once up front so sorting is a cheap comparison
+	// of precomputed keys, not a re-run of the matcher per comparison.
+	kept := make([]scored, 0, len(fb.items))
+	for _, it := range fb.items {
+		s := score(it)
+		if math.IsInf(s, -1) || s < cfg.minScore {
+			continue
+		}
+		kept = append(kept, scored{it, s})
+	}
+
+	if cfg.sortByScore {
+		sort.SliceStable(kept, func(i, j int) bool {
+			return kept[i].score > kept[j].score
+		})
+	}
+
+	out := &Feedback{vars: fb.vars}
+	for _, k := range kept {
+		out.items = append(out.items, k.item)
+	}
+	return out
+}
+
+// scoreItem scores query against it's title, adding a discounted bonus
+// for a subtitle match. ok is false if query doesn't match the title at
+// all, in which case the Item should be dropped regardless of score.
+func scoreItem(query string, it *Item) (score float64, ok bool) {
+	titleScore, ok := scoreString(query, it.title)
+	if !ok {
+		return 0, false
+	}
+	score = titleScore
+
+	if it.subtitle != nil {
+		if subScore, ok := scoreString(query, *it.subtitle); ok {
+			score += subScore * subtitleDiscount
+		}
+	}
+	return score, true
+}
+
+// scoreString fuzzy-matches query against target and returns its score.
+// ok is false if some rune of query never matched, in which case score
+// is meaningless and the caller should treat it as no match.
+func scoreString(query, target string) (score float64, ok bool) {
+	nq := []rune(normalize(query))
+	if len(nq) == 0 {
+		return 0, true
+	}
+
+	ot := []rune(target)
+	nt := []rune(normalize(target))
+
+	qi, run := 0, 0
+	skipped := 0.0
+
+	for ti := 0; ti < len(nt) && qi < len(nq); ti++ {
+		if nt[ti] != nq[qi] {
+			run = 0
+			if skipped > maxSkipPenalty {
+				skipped += scoreSkip
+			}
+			continue
+		}
+
+		switch {
+		case ti == 0 || isPathSeparator(ot[ti-1]):
+			score += scoreBoundary
+		case unicode.IsLower(ot[ti-1]) && unicode.IsUpper(ot[ti]):
+			score += scoreCamelCase
+		}
+
+		run++
+		score += scoreConsecutive * float64(run-1)
+		qi++
+	}
+
+	score += skipped
+
+	if qi < len(nq) {
+		score += scoreUnmatched * float64(len(nq)-qi)
+		return score, false
+	}
+	return score, true
+}
+
+func isPathSeparator(r rune) bool {
+	switch r {
+	case '/', '-', '_', ' ', '.':
+		return true
+	}
+	return false
+}
+
+// diacritics covers the common precomposed Latin accented characters.
+// It's a pragmatic stand-in for a full NFD-decompose-then-strip pass:
+// enough for the workflow titles and filenames people actually search,
+// without pulling in a normalisation library for it.
+var diacritics = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ä", "a", "ã", "a", "å", "a",
+	"é", "e", "è", "e", "ê", "e", "ë", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i",
+	"ó", "o", "ò", "o", "ô", "o", "ö", "o", "õ", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u",
+	"ñ", "n", "ç", "c", "ý", "y", "ÿ", "y",
+)
+
+// normalize lower-cases s and strips common diacritics, so e.g. "cafe"
+// matches "Café".
+func normalize(s string) string {
+	return strings.ToLower(diacritics.Replace(s))
+}
@@ -0,0 +1,78 @@
+//
+// Copyright (c) 2016 Dean Jackson <deanishe@deanishe.net>
+//
+// MIT Licence. See http://opensource.org/licenses/MIT
+//
+
+package workflow
+
+import "testing"
+
+func TestFeedbackFilter(t *testing.T) {
+	fb := &Feedback{items: []*Item{
+		{title: "Safari.app"},
+		{title: "System Preferences.app"},
+		{title: "Terminal.app"},
+	}}
+
+	out := fb.Filter("term")
+	if len(out.items) != 1 || out.items[0].title != "Terminal.app" {
+		t.Fatalf("unexpected result: %#v", out.items)
+	}
+
+	out = fb.Filter("zzzzz")
+	if len(out.items) != 0 {
+		t.Fatalf("expected no matches, got %#v", out.items)
+	}
+}
+
+func TestFeedbackFilterOrdering(t *testing.T) {
+	// "sp" matches "System Preferences.app" at two word boundaries,
+	// but in "Safari.app" only the "s" is at a boundary and the "p" is
+	// mid-word, after more skipped characters, so it should rank first.
+	fb := &Feedback{items: []*Item{
+		{title: "Safari.app"},
+		{title: "System Preferences.app"},
+	}}
+
+	out := fb.Filter("sp")
+	if len(out.items) != 2 {
+		t.Fatalf("expected 2 matches, got %d", len(out.items))
+	}
+	if out.items[0].title != "System Preferences.app" {
+		t.Fatalf("expected System Preferences.app first, got %v", out.items[0].title)
+	}
+}
+
+func TestFeedbackFilterMinScore(t *testing.T) {
+	fb := &Feedback{items: []*Item{
+		{title: "Terminal.app"},
+		{title: "xtxextxexrxmxixnxaxlx.app"},
+	}}
+
+	out := fb.Filter("term", MinScore(20))
+	for _, it := range out.items {
+		if it.title != "Terminal.app" {
+			t.Fatalf("low-scoring item survived MinScore filter: %v", it.title)
+		}
+	}
+}
+
+func TestFeedbackFilterFunc(t *testing.T) {
+	fb := &Feedback{items: []*Item{
+		{title: "a"},
+		{title: "b"},
+		{title: "c"},
+	}}
+
+	out := fb.FilterFunc(func(it *Item) float64 {
+		if it.title == "b" {
+			return 1
+		}
+		return -1
+	}, MinScore(0))
+
+	if len(out.items) != 1 || out.items[0].title != "b" {
+		t.Fatalf("unexpected result: %#v", out.items)
+	}
+}
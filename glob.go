@@ -0,0 +1,226 @@
+//
+// Copyright (c) 2016 Dean Jackson <deanishe@deanishe.net>
+//
+// MIT Licence. See http://opensource.org/licenses/MIT
+//
+
+package workflow
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// GlobOptions controls the behaviour of Feedback.NewFileItemsOptions.
+type GlobOptions struct {
+	// Recursive descends into matched directories, adding an Item for
+	// every file beneath them. NewFileItems leaves this off;
+	// NewFileItemsRecursive turns it on.
+	Recursive bool
+	// IncludeHidden includes dotfiles and dot-directories. If false
+	// (the default), hidden directories are not descended into either.
+	IncludeHidden bool
+	// FollowSymlinks follows symlinks (to directories and to files)
+	// when walking. If false (the default), symlinks beneath a matched
+	// directory are skipped, whatever they point to.
+	FollowSymlinks bool
+	// MaxDepth limits how many directories to descend into below each
+	// glob match. Zero (the default) means unlimited.
+	MaxDepth int
+}
+
+// DefaultGlobOptions are the options used by NewFileItems.
+var DefaultGlobOptions = GlobOptions{
+	IncludeHidden:  false,
+	FollowSymlinks: false,
+	MaxDepth:       0,
+}
+
+// NewFileItems expands pattern with filepath.Glob and adds an Item
+// (built the same way NewFileItem builds one) for every match, in
+// lexicographic path order. Like NewFileItem, it both appends the new
+// Items to fb and returns them, so callers can tweak them (e.g. call
+// Var) without having to add them to fb themselves.
+//
+// Matched directories are not descended into; use NewFileItemsRecursive
+// or NewFileItemsOptions to walk them.
+func (fb *Feedback) NewFileItems(pattern string) ([]*Item, error) {
+	return fb.NewFileItemsOptions(pattern, DefaultGlobOptions)
+}
+
+// NewFileItemsRecursive is NewFileItems with directory walking turned
+// on: every matched directory contributes an Item for each of its
+// descendant files.
+func (fb *Feedback) NewFileItemsRecursive(pattern string) ([]*Item, error) {
+	opts := DefaultGlobOptions
+	opts.Recursive = true
+	return fb.NewFileItemsOptions(pattern, opts)
+}
+
+// NewFileItemsOptions is the fully configurable version of
+// NewFileItems. pattern is resolved with filepath.Glob, then each match
+// is turned into an Item (or, for directories, walked per opts and
+// turned into one Item per descendant file), in lexicographic path
+// order. The new Items are appended to fb and also returned.
+//
+// Items are built by a bounded pool of runtime.NumCPU() goroutines.
+// The os.Stat of each glob match and the directory reads of the walk
+// are not spread across that pool: they run one at a time in the
+// calling goroutine, which feeds paths to the pool. NewFileItem itself appends
+// straight to fb.items, which isn't safe to call from multiple
+// goroutines at once, so the workers build Items with the unattached
+// newFileItem instead and fb.items is only touched here, single-
+// threaded, once every worker is done.
+func (fb *Feedback) NewFileItemsOptions(pattern string, opts GlobOptions) ([]*Item, error) {
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	type found struct {
+		path string
+		item *Item
+	}
+
+	paths := make(chan string)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var results []found
+
+	workers := runtime.NumCPU()
+	if workers < 1 {
+		workers = 1
+	}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range paths {
+				it := newFileItem(p)
+				mu.Lock()
+				results = append(results, found{p, it})
+				mu.Unlock()
+			}
+		}()
+	}
+
+	var walkErr error
+	for _, m := range matches {
+		if !opts.IncludeHidden && isHiddenPath(m) {
+			continue
+		}
+		fi, err := os.Stat(m)
+		if err != nil {
+			continue
+		}
+		if fi.IsDir() {
+			if opts.Recursive {
+				if err := walkDir(m, opts, 0, paths); err != nil && walkErr == nil {
+					walkErr = err
+				}
+			}
+			continue
+		}
+		paths <- m
+	}
+	close(paths)
+	wg.Wait()
+
+	sort.Slice(results, func(i, j int) bool { return results[i].path < results[j].path })
+
+	items := make([]*Item, len(results))
+	for i, f := range results {
+		items[i] = f.item
+	}
+	fb.items = append(fb.items, items...)
+
+	return items, walkErr
+}
+
+// newFileItem builds the Item Feedback.NewFileItem would build for
+// path, without appending it to a Feedback. It lets
+// NewFileItemsOptions build Items concurrently and append them to
+// fb.items itself afterwards, rather than having every worker goroutine
+// race on the same Feedback.
+func newFileItem(path string) *Item {
+	subtitle := prettifyPath(path)
+	uid := path
+	return &Item{
+		title:    filepath.Base(path),
+		subtitle: &subtitle,
+		uid:      &uid,
+		file:     true,
+		icon:     &Icon{Value: path, Type: "fileicon"},
+	}
+}
+
+// prettifyPath replaces every occurrence of the user's home directory
+// in path with "~", the same way NewFileItem builds its subtitle.
+func prettifyPath(path string) string {
+	home := os.Getenv("HOME")
+	if home == "" {
+		return path
+	}
+	return strings.Replace(path, home, "~", -1)
+}
+
+// walkDir sends path on paths for every file beneath dir, honouring
+// opts.IncludeHidden, opts.FollowSymlinks and opts.MaxDepth.
+func walkDir(dir string, opts GlobOptions, depth int, paths chan<- string) error {
+	if opts.MaxDepth > 0 && depth >= opts.MaxDepth {
+		return nil
+	}
+
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		if !opts.IncludeHidden && isHiddenName(e.Name()) {
+			continue
+		}
+		p := filepath.Join(dir, e.Name())
+
+		if e.Mode()&os.ModeSymlink != 0 {
+			if !opts.FollowSymlinks {
+				continue
+			}
+			fi, err := os.Stat(p) // Stat follows symlinks
+			if err != nil {
+				continue
+			}
+			if fi.IsDir() {
+				if err := walkDir(p, opts, depth+1, paths); err != nil {
+					return err
+				}
+				continue
+			}
+			paths <- p
+			continue
+		}
+
+		if e.IsDir() {
+			if err := walkDir(p, opts, depth+1, paths); err != nil {
+				return err
+			}
+			continue
+		}
+
+		paths <- p
+	}
+	return nil
+}
+
+func isHiddenPath(path string) bool {
+	return isHiddenName(filepath.Base(path))
+}
+
+func isHiddenName(name string) bool {
+	return len(name) > 0 && name[0] == '.'
+}
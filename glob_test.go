@@ -0,0 +1,161 @@
+//
+// Copyright (c) 2016 Dean Jackson <deanishe@deanishe.net>
+//
+// MIT Licence. See http://opensource.org/licenses/MIT
+//
+
+package workflow
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+// setupGlobTree creates:
+//
+//	<tmp>/top.txt
+//	<tmp>/.hidden.txt
+//	<tmp>/sub/nested.txt
+//	<tmp>/sub/deeper/double-nested.txt
+//
+// and returns tmp.
+func setupGlobTree(t *testing.T) string {
+	tmp, err := ioutil.TempDir("", "awgo-glob-test")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(tmp) })
+
+	files := []string{
+		"top.txt",
+		".hidden.txt",
+		filepath.Join("sub", "nested.txt"),
+		filepath.Join("sub", "deeper", "double-nested.txt"),
+	}
+	for _, rel := range files {
+		p := filepath.Join(tmp, rel)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatalf("MkdirAll(%v): %v", p, err)
+		}
+		if err := ioutil.WriteFile(p, []byte("x"), 0644); err != nil {
+			t.Fatalf("WriteFile(%v): %v", p, err)
+		}
+	}
+	return tmp
+}
+
+func titles(items []*Item) []string {
+	var ts []string
+	for _, it := range items {
+		ts = append(ts, it.title)
+	}
+	sort.Strings(ts)
+	return ts
+}
+
+func TestNewFileItemsNonRecursive(t *testing.T) {
+	tmp := setupGlobTree(t)
+	fb := &Feedback{}
+
+	items, err := fb.NewFileItems(filepath.Join(tmp, "*.txt"))
+	if err != nil {
+		t.Fatalf("NewFileItems: %v", err)
+	}
+
+	got := titles(items)
+	want := []string{"top.txt"}
+	if len(got) != len(want) || got[0] != want[0] {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestNewFileItemsRecursive(t *testing.T) {
+	tmp := setupGlobTree(t)
+	fb := &Feedback{}
+
+	items, err := fb.NewFileItemsRecursive(filepath.Join(tmp, "sub"))
+	if err != nil {
+		t.Fatalf("NewFileItemsRecursive: %v", err)
+	}
+
+	got := titles(items)
+	want := []string{"double-nested.txt", "nested.txt"}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestNewFileItemsOrder(t *testing.T) {
+	tmp := setupGlobTree(t)
+	fb := &Feedback{}
+
+	items, err := fb.NewFileItemsRecursive(filepath.Join(tmp, "sub"))
+	if err != nil {
+		t.Fatalf("NewFileItemsRecursive: %v", err)
+	}
+
+	// Unlike titles(), this does not sort first: the returned order
+	// must already be stable (lexicographic by full path, so
+	// ".../sub/deeper/double-nested.txt" sorts before
+	// ".../sub/nested.txt"), not an artefact of whichever worker
+	// goroutine finished first.
+	want := []string{"double-nested.txt", "nested.txt"}
+	if len(items) != len(want) {
+		t.Fatalf("got %d items, want %d", len(items), len(want))
+	}
+	for i, w := range want {
+		if items[i].title != w {
+			t.Fatalf("got order %v, want %v", titles(items), want)
+		}
+	}
+}
+
+func TestNewFileItemsOwnership(t *testing.T) {
+	tmp := setupGlobTree(t)
+	fb := &Feedback{}
+
+	items, err := fb.NewFileItems(filepath.Join(tmp, "*.txt"))
+	if err != nil {
+		t.Fatalf("NewFileItems: %v", err)
+	}
+
+	// NewFileItems must add each Item to fb exactly once: it's already
+	// appended to fb.items, so the caller must not need to (and must
+	// not accidentally) append the returned slice again.
+	if len(fb.items) != len(items) {
+		t.Fatalf("fb.items has %d items, want %d", len(fb.items), len(items))
+	}
+	for i := range items {
+		if fb.items[i] != items[i] {
+			t.Fatalf("fb.items[%d] is not the Item NewFileItems returned", i)
+		}
+	}
+}
+
+func TestNewFileItemsMaxDepth(t *testing.T) {
+	tmp := setupGlobTree(t)
+	fb := &Feedback{}
+
+	opts := DefaultGlobOptions
+	opts.Recursive = true
+	opts.MaxDepth = 1
+
+	items, err := fb.NewFileItemsOptions(filepath.Join(tmp, "sub"), opts)
+	if err != nil {
+		t.Fatalf("NewFileItemsOptions: %v", err)
+	}
+
+	got := titles(items)
+	want := []string{"nested.txt"}
+	if len(got) != len(want) || got[0] != want[0] {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
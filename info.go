@@ -0,0 +1,33 @@
+//
+// Copyright (c) 2016 Dean Jackson <deanishe@deanishe.net>
+//
+// MIT Licence. See http://opensource.org/licenses/MIT
+//
+
+package workflow
+
+import (
+	"path/filepath"
+	"sync"
+
+	"github.com/golanglib/awgo/plist"
+)
+
+var (
+	bundleInfo     *plist.BundleInfo
+	bundleInfoErr  error
+	bundleInfoOnce sync.Once
+)
+
+// Info parses the workflow's info.plist (found via Dir) and returns
+// its bundle metadata: bundle ID, name, version, author, declared
+// variables and the UID/Type of every object in the workflow.
+//
+// The plist is parsed once per process and the result cached, so
+// repeated calls are cheap.
+func Info() (*plist.BundleInfo, error) {
+	bundleInfoOnce.Do(func() {
+		bundleInfo, bundleInfoErr = plist.ParseFile(filepath.Join(Dir(), "info.plist"))
+	})
+	return bundleInfo, bundleInfoErr
+}
@@ -0,0 +1,153 @@
+//
+// Copyright (c) 2016 Dean Jackson <deanishe@deanishe.net>
+//
+// MIT Licence. See http://opensource.org/licenses/MIT
+//
+
+package plist
+
+import (
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// decode reads an Apple-format XML plist and returns its root value:
+// one of map[string]interface{}, []interface{}, string, int, float64 or
+// bool. <date> and <data> elements are skipped; info.plist doesn't use
+// either for anything this package cares about.
+func decode(r io.Reader) (interface{}, error) {
+	dec := xml.NewDecoder(r)
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		if se, ok := tok.(xml.StartElement); ok && se.Name.Local == "plist" {
+			return decodeValue(dec)
+		}
+	}
+}
+
+// decodeValue reads the next value from dec, skipping whitespace
+// CharData between elements.
+func decodeValue(dec *xml.Decoder) (interface{}, error) {
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			return decodeElement(dec, t)
+		case xml.EndElement:
+			return nil, nil
+		}
+	}
+}
+
+// decodeElement decodes the value of the element start has already
+// been read for.
+func decodeElement(dec *xml.Decoder, start xml.StartElement) (interface{}, error) {
+	switch start.Name.Local {
+	case "dict":
+		return decodeDict(dec)
+	case "array":
+		return decodeArray(dec)
+	case "string":
+		return decodeText(dec)
+	case "integer":
+		s, err := decodeText(dec)
+		if err != nil {
+			return nil, err
+		}
+		return strconv.Atoi(s)
+	case "real":
+		s, err := decodeText(dec)
+		if err != nil {
+			return nil, err
+		}
+		return strconv.ParseFloat(s, 64)
+	case "true":
+		return true, dec.Skip()
+	case "false":
+		return false, dec.Skip()
+	default:
+		// date, data and anything else: not needed, so just skip it.
+		return nil, dec.Skip()
+	}
+}
+
+// decodeText returns the character data up to the next end element,
+// i.e. the contents of a leaf element like <string>.
+func decodeText(dec *xml.Decoder) (string, error) {
+	var sb strings.Builder
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return "", err
+		}
+		switch t := tok.(type) {
+		case xml.CharData:
+			sb.Write(t)
+		case xml.EndElement:
+			return sb.String(), nil
+		}
+	}
+}
+
+// decodeDict decodes a <dict>...</dict> into a map keyed by <key>
+// value, preserved verbatim. Most of info.plist's dicts are keyed by
+// fixed, already-lower-case schema names ("bundleid", "variables", ...)
+// but "variables" and "connections" are keyed by arbitrary,
+// case-sensitive variable names and object UIDs, so keys can't be
+// folded to lower-case here.
+func decodeDict(dec *xml.Decoder) (map[string]interface{}, error) {
+	d := map[string]interface{}{}
+	var key string
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if t.Name.Local == "key" {
+				key, err = decodeText(dec)
+				if err != nil {
+					return nil, err
+				}
+				continue
+			}
+			v, err := decodeElement(dec, t)
+			if err != nil {
+				return nil, err
+			}
+			d[key] = v
+		case xml.EndElement:
+			return d, nil
+		}
+	}
+}
+
+// decodeArray decodes an <array>...</array> into a slice.
+func decodeArray(dec *xml.Decoder) ([]interface{}, error) {
+	var a []interface{}
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			v, err := decodeElement(dec, t)
+			if err != nil {
+				return nil, err
+			}
+			a = append(a, v)
+		case xml.EndElement:
+			return a, nil
+		}
+	}
+}
@@ -0,0 +1,161 @@
+//
+// Copyright (c) 2016 Dean Jackson <deanishe@deanishe.net>
+//
+// MIT Licence. See http://opensource.org/licenses/MIT
+//
+
+// Package plist parses an Alfred workflow's info.plist into a typed
+// BundleInfo, so a workflow can key off its own real identity (bundle
+// ID, declared variables, UIDs of its own objects) instead of relying
+// on environment variables set by Alfred at runtime.
+package plist
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// BundleInfo is the subset of an Alfred workflow's info.plist that
+// workflow code typically needs.
+type BundleInfo struct {
+	// BundleID is the workflow's bundle identifier, e.g.
+	// "net.deanishe.alfred-workflow".
+	BundleID string
+	// Name is the workflow's display name.
+	Name string
+	// Version is the workflow's version string.
+	Version string
+	// Author is the workflow's declared author.
+	Author string
+
+	// Variables holds the workflow's declared configuration
+	// variables, i.e. the top-level "variables" dict.
+	Variables map[string]string
+
+	// VariablesDontExport lists the names of Variables that are
+	// marked "don't export", i.e. not passed to child processes.
+	VariablesDontExport []string
+
+	// Objects holds every object (script filter, action, etc.) in the
+	// workflow, keyed by UID.
+	Objects map[string]Object
+
+	// Connections maps an object's UID to the objects its outputs are
+	// wired to.
+	Connections map[string][]Connection
+}
+
+// Object is a single entry in info.plist's "objects" array.
+type Object struct {
+	UID  string
+	Type string
+}
+
+// Connection is one destination of a wire leaving an Object.
+type Connection struct {
+	DestinationUID string
+	ModifierMask   int
+}
+
+// Exported reports whether name is a declared workflow variable that
+// is not listed in VariablesDontExport.
+func (bi *BundleInfo) Exported(name string) bool {
+	if _, ok := bi.Variables[name]; !ok {
+		return false
+	}
+	for _, n := range bi.VariablesDontExport {
+		if n == name {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseFile parses the info.plist at path.
+func ParseFile(path string) (*BundleInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// Parse parses an Alfred info.plist read from r.
+func Parse(r io.Reader) (*BundleInfo, error) {
+	root, err := decode(r)
+	if err != nil {
+		return nil, fmt.Errorf("decode info.plist: %v", err)
+	}
+
+	dict, ok := root.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("info.plist: root element is not a dict")
+	}
+
+	bi := &BundleInfo{
+		Variables:   map[string]string{},
+		Objects:     map[string]Object{},
+		Connections: map[string][]Connection{},
+	}
+
+	bi.BundleID, _ = dict["bundleid"].(string)
+	bi.Name, _ = dict["name"].(string)
+	bi.Version, _ = dict["version"].(string)
+	bi.Author, _ = dict["createdby"].(string)
+
+	if vars, ok := dict["variables"].(map[string]interface{}); ok {
+		for k, v := range vars {
+			if s, ok := v.(string); ok {
+				bi.Variables[k] = s
+			}
+		}
+	}
+
+	if dontExport, ok := dict["variablesdontexport"].([]interface{}); ok {
+		for _, v := range dontExport {
+			if s, ok := v.(string); ok {
+				bi.VariablesDontExport = append(bi.VariablesDontExport, s)
+			}
+		}
+	}
+
+	if objs, ok := dict["objects"].([]interface{}); ok {
+		for _, o := range objs {
+			od, ok := o.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			uid, _ := od["uid"].(string)
+			if uid == "" {
+				continue
+			}
+			typ, _ := od["type"].(string)
+			bi.Objects[uid] = Object{UID: uid, Type: typ}
+		}
+	}
+
+	if conns, ok := dict["connections"].(map[string]interface{}); ok {
+		for srcUID, v := range conns {
+			list, ok := v.([]interface{})
+			if !ok {
+				continue
+			}
+			for _, c := range list {
+				cd, ok := c.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				destUID, _ := cd["destinationuid"].(string)
+				mask, _ := cd["modifiers"].(int)
+				bi.Connections[srcUID] = append(bi.Connections[srcUID], Connection{
+					DestinationUID: destUID,
+					ModifierMask:   mask,
+				})
+			}
+		}
+	}
+
+	return bi, nil
+}
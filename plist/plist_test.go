@@ -0,0 +1,112 @@
+//
+// Copyright (c) 2016 Dean Jackson <deanishe@deanishe.net>
+//
+// MIT Licence. See http://opensource.org/licenses/MIT
+//
+
+package plist
+
+import (
+	"strings"
+	"testing"
+)
+
+const testPlist = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>bundleid</key>
+	<string>net.deanishe.alfred-test</string>
+	<key>name</key>
+	<string>Test Workflow</string>
+	<key>version</key>
+	<string>1.2.0</string>
+	<key>createdby</key>
+	<string>Dean Jackson</string>
+	<key>variables</key>
+	<dict>
+		<key>api_key</key>
+		<string>secret</string>
+		<key>debug</key>
+		<string>0</string>
+	</dict>
+	<key>variablesdontexport</key>
+	<array>
+		<string>api_key</string>
+	</array>
+	<key>objects</key>
+	<array>
+		<dict>
+			<key>uid</key>
+			<string>AAAA-0001</string>
+			<key>type</key>
+			<string>alfred.workflow.input.scriptfilter</string>
+		</dict>
+	</array>
+	<key>connections</key>
+	<dict>
+		<key>AAAA-0001</key>
+		<array>
+			<dict>
+				<key>destinationuid</key>
+				<string>BBBB-0002</string>
+				<key>modifiers</key>
+				<integer>0</integer>
+			</dict>
+		</array>
+	</dict>
+</dict>
+</plist>
+`
+
+func TestParse(t *testing.T) {
+	bi, err := Parse(strings.NewReader(testPlist))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	if bi.BundleID != "net.deanishe.alfred-test" {
+		t.Errorf("BundleID = %v", bi.BundleID)
+	}
+	if bi.Name != "Test Workflow" {
+		t.Errorf("Name = %v", bi.Name)
+	}
+	if bi.Version != "1.2.0" {
+		t.Errorf("Version = %v", bi.Version)
+	}
+	if bi.Author != "Dean Jackson" {
+		t.Errorf("Author = %v", bi.Author)
+	}
+	if bi.Variables["api_key"] != "secret" {
+		t.Errorf("Variables[api_key] = %v", bi.Variables["api_key"])
+	}
+	if bi.Variables["debug"] != "0" {
+		t.Errorf("Variables[debug] = %v", bi.Variables["debug"])
+	}
+
+	if bi.Exported("api_key") {
+		t.Error("api_key should not be exported")
+	}
+	if !bi.Exported("debug") {
+		t.Error("debug should be exported")
+	}
+	if bi.Exported("nonexistent") {
+		t.Error("nonexistent variable should not be exported")
+	}
+
+	obj, ok := bi.Objects["AAAA-0001"]
+	if !ok {
+		t.Fatal("object AAAA-0001 not found")
+	}
+	if obj.Type != "alfred.workflow.input.scriptfilter" {
+		t.Errorf("Type = %v", obj.Type)
+	}
+
+	conns := bi.Connections["AAAA-0001"]
+	if len(conns) != 1 {
+		t.Fatalf("expected 1 connection, got %d", len(conns))
+	}
+	if conns[0].DestinationUID != "BBBB-0002" {
+		t.Errorf("DestinationUID = %v", conns[0].DestinationUID)
+	}
+}